@@ -0,0 +1,185 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+// AliasStore resolves an OPC UA NodeID string (e.g. "ns=4;i=1001") to an
+// operator-assigned friendly name, and pushes updates as they happen so a
+// hot-reloading AliasResolver can keep its cache current without restarting
+// Benthos.
+type AliasStore interface {
+	// Get returns the friendly name for nodeID, if one is configured.
+	Get(ctx context.Context, nodeID string) (name string, ok bool, err error)
+	// Watch blocks, invoking onUpdate once for every known alias and again
+	// whenever one changes, until ctx is canceled. A nodeID whose alias is
+	// removed from the backing store is reported as onUpdate(nodeID, ""),
+	// so callers must treat an empty name as a deletion rather than a valid
+	// alias.
+	Watch(ctx context.Context, onUpdate func(nodeID, name string)) error
+}
+
+// InlineAliasStore serves a static NodeID->name map supplied directly in the
+// input's configuration.
+type InlineAliasStore struct {
+	aliases map[string]string
+}
+
+// NewInlineAliasStore returns an AliasStore backed by a fixed map.
+func NewInlineAliasStore(aliases map[string]string) *InlineAliasStore {
+	return &InlineAliasStore{aliases: aliases}
+}
+
+func (s *InlineAliasStore) Get(_ context.Context, nodeID string) (string, bool, error) {
+	name, ok := s.aliases[nodeID]
+	return name, ok, nil
+}
+
+// Watch replays every configured alias once and then blocks until ctx is
+// canceled, since an inline map never changes at runtime.
+func (s *InlineAliasStore) Watch(ctx context.Context, onUpdate func(nodeID, name string)) error {
+	for nodeID, name := range s.aliases {
+		onUpdate(nodeID, name)
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// KVClient is the minimal read interface a KV backend (etcd, consul, redis,
+// ...) must provide to back a PollingAliasStore.
+type KVClient interface {
+	// List returns every key under prefix with the prefix stripped, e.g.
+	// "aliases/ns=4;i=1001" -> "ns=4;i=1001".
+	List(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// PollingAliasStore adapts a poll-only KVClient into the AliasStore
+// interface by re-listing prefix on every interval and diffing against the
+// previous snapshot.
+type PollingAliasStore struct {
+	client   KVClient
+	prefix   string
+	interval time.Duration
+}
+
+// NewPollingAliasStore returns an AliasStore that polls client every
+// interval for keys under prefix.
+func NewPollingAliasStore(client KVClient, prefix string, interval time.Duration) *PollingAliasStore {
+	return &PollingAliasStore{client: client, prefix: prefix, interval: interval}
+}
+
+func (s *PollingAliasStore) Get(ctx context.Context, nodeID string) (string, bool, error) {
+	current, err := s.client.List(ctx, s.prefix)
+	if err != nil {
+		return "", false, err
+	}
+	name, ok := current[nodeID]
+	return name, ok, nil
+}
+
+func (s *PollingAliasStore) Watch(ctx context.Context, onUpdate func(nodeID, name string)) error {
+	last := map[string]string{}
+
+	poll := func() error {
+		current, err := s.client.List(ctx, s.prefix)
+		if err != nil {
+			return err
+		}
+		for nodeID, name := range current {
+			if last[nodeID] != name {
+				onUpdate(nodeID, name)
+			}
+		}
+		for nodeID := range last {
+			if _, ok := current[nodeID]; !ok {
+				// Alias removed at the backing store since the last poll:
+				// report it so the resolver drops it instead of serving the
+				// stale name forever.
+				onUpdate(nodeID, "")
+			}
+		}
+		last = current
+		return nil
+	}
+
+	if err := poll(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_ = poll()
+		}
+	}
+}
+
+// AliasResolver keeps a sync.Map of the latest NodeID->name aliases pushed
+// by an AliasStore's Watch, so the hot read path can resolve names without
+// blocking on the backing store.
+type AliasResolver struct {
+	store AliasStore
+	cache sync.Map
+}
+
+// NewAliasResolver starts watching store in the background and returns an
+// AliasResolver whose TagName lookups are served from an in-memory cache.
+// The watch goroutine stops when ctx is canceled.
+func NewAliasResolver(ctx context.Context, store AliasStore, log *service.Logger) *AliasResolver {
+	r := &AliasResolver{store: store}
+
+	go func() {
+		if err := store.Watch(ctx, func(nodeID, name string) {
+			if name == "" {
+				r.cache.Delete(nodeID)
+				return
+			}
+			r.cache.Store(nodeID, name)
+		}); err != nil && ctx.Err() == nil && log != nil {
+			log.Errorf("Alias store watch stopped: %v", err)
+		}
+	}()
+
+	return r
+}
+
+// TagName returns the friendly name cached for nodeID, or fallback if none
+// has been pushed yet.
+func (r *AliasResolver) TagName(nodeID string, fallback string) string {
+	if v, ok := r.cache.Load(nodeID); ok {
+		return v.(string)
+	}
+	return fallback
+}
+
+// Annotate sets tag_name/browse_name metadata to the resolved alias (or
+// fallback) while preserving the original NodeID in a separate field, so
+// downstream pipelines can always recover it.
+func (r *AliasResolver) Annotate(msg *service.Message, nodeID string, fallback string) {
+	tagName := r.TagName(nodeID, fallback)
+	msg.MetaSetMut("tag_name", tagName)
+	msg.MetaSetMut("browse_name", tagName)
+	msg.MetaSetMut("node_id", nodeID)
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+// fakeKVClient is an in-memory KVClient used to drive PollingAliasStore in
+// tests without a real etcd/consul/redis backend.
+type fakeKVClient struct {
+	data map[string]string
+}
+
+func (c *fakeKVClient) List(_ context.Context, _ string) (map[string]string, error) {
+	out := make(map[string]string, len(c.data))
+	for k, v := range c.data {
+		out[k] = v
+	}
+	return out, nil
+}
+
+var _ = Describe("AliasResolver", func() {
+	BeforeEach(func() {
+		if os.Getenv("TEST_OPCUA_UNIT") == "" {
+			Skip("Skipping OPC UA unit tests: TEST_OPCUA_UNIT not set")
+		}
+	})
+
+	It("resolves names from an inline alias store", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := NewInlineAliasStore(map[string]string{
+			"ns=4;i=1001": "Line1.Motor.Speed",
+		})
+		resolver := NewAliasResolver(ctx, store, service.MockResources().Logger())
+
+		Eventually(func() string {
+			return resolver.TagName("ns=4;i=1001", "")
+		}).Should(Equal("Line1.Motor.Speed"))
+	})
+
+	It("falls back to the supplied default when no alias is known", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := NewInlineAliasStore(map[string]string{})
+		resolver := NewAliasResolver(ctx, store, service.MockResources().Logger())
+
+		Expect(resolver.TagName("ns=4;i=9999", "RawNodeID")).To(Equal("RawNodeID"))
+	})
+
+	It("annotates a message with resolved alias metadata while keeping the NodeID", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := NewInlineAliasStore(map[string]string{
+			"ns=4;i=1001": "Line1.Motor.Speed",
+		})
+		resolver := NewAliasResolver(ctx, store, service.MockResources().Logger())
+
+		Eventually(func() string {
+			return resolver.TagName("ns=4;i=1001", "")
+		}).Should(Equal("Line1.Motor.Speed"))
+
+		msg := service.NewMessage(nil)
+		resolver.Annotate(msg, "ns=4;i=1001", "fallback")
+
+		tagName, exists := msg.MetaGetMut("tag_name")
+		Expect(exists).To(BeTrue())
+		Expect(tagName).To(Equal("Line1.Motor.Speed"))
+
+		nodeID, exists := msg.MetaGetMut("node_id")
+		Expect(exists).To(BeTrue())
+		Expect(nodeID).To(Equal("ns=4;i=1001"))
+	})
+
+	It("picks up updates pushed by a polling KV-backed store", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client := &fakeKVClient{data: map[string]string{}}
+		store := NewPollingAliasStore(client, "aliases/", time.Millisecond*10)
+		resolver := NewAliasResolver(ctx, store, service.MockResources().Logger())
+
+		Expect(resolver.TagName("ns=4;i=2002", "fallback")).To(Equal("fallback"))
+
+		client.data["ns=4;i=2002"] = "Line2.Valve.Open"
+
+		Eventually(func() string {
+			return resolver.TagName("ns=4;i=2002", "fallback")
+		}, "1s", "10ms").Should(Equal("Line2.Valve.Open"))
+	})
+
+	It("drops an alias that disappears from a polling KV-backed store", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client := &fakeKVClient{data: map[string]string{
+			"ns=4;i=2003": "Line3.Pump.Running",
+		}}
+		store := NewPollingAliasStore(client, "aliases/", time.Millisecond*10)
+		resolver := NewAliasResolver(ctx, store, service.MockResources().Logger())
+
+		Eventually(func() string {
+			return resolver.TagName("ns=4;i=2003", "fallback")
+		}, "1s", "10ms").Should(Equal("Line3.Pump.Running"))
+
+		delete(client.data, "ns=4;i=2003")
+
+		Eventually(func() string {
+			return resolver.TagName("ns=4;i=2003", "fallback")
+		}, "1s", "10ms").Should(Equal("fallback"))
+	})
+})
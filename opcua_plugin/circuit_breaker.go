@@ -0,0 +1,208 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// breakerState is the internal state of a CircuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker guards OPCUAConnection.Read against reconnect storms when a
+// server is flaky: once failureThreshold consecutive transport failures
+// occur within window, the breaker opens and rejects calls for cooldown
+// before allowing a single half-open probe through.
+type CircuitBreaker struct {
+	mu sync.Mutex
+
+	failureThreshold int
+	window           time.Duration
+	cooldown         time.Duration
+
+	state    breakerState
+	failures []time.Time
+	openedAt time.Time
+
+	// halfOpenProbeInFlight is true while a single half-open probe is
+	// outstanding, so a second concurrent/retried caller is rejected instead
+	// of also being let through to the still-recovering server.
+	halfOpenProbeInFlight bool
+
+	// consecutiveFailures counts failures since the last success, independent
+	// of the windowed failures slice (which trip() clears). Minus one, it is
+	// the 0-based attempt number callers pass to NextBackoff for the
+	// reconnect delay.
+	consecutiveFailures int
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker that opens after
+// failureThreshold failures within window, and stays open for cooldown.
+func NewCircuitBreaker(failureThreshold int, window, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		window:           window,
+		cooldown:         cooldown,
+		state:            breakerClosed,
+	}
+}
+
+// Allow reports whether a call should be attempted. In the open state it
+// transitions to half-open once cooldown has elapsed since the breaker
+// tripped, and in the half-open state it admits exactly one in-flight probe
+// at a time — further callers are rejected until that probe reports success
+// or failure.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.halfOpenProbeInFlight = true
+		return true
+	case breakerHalfOpen:
+		if b.halfOpenProbeInFlight {
+			return false
+		}
+		b.halfOpenProbeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the breaker and resets its failure history.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = nil
+	b.halfOpenProbeInFlight = false
+	b.consecutiveFailures = 0
+}
+
+// RecordFailure counts a transport-level failure. A failure while
+// half-open re-opens the breaker immediately; otherwise the breaker trips
+// once failureThreshold failures have landed within window.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.consecutiveFailures++
+
+	if b.state == breakerHalfOpen {
+		b.trip(now)
+		return
+	}
+
+	cutoff := now.Add(-b.window)
+	kept := b.failures[:0]
+	for _, t := range b.failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	b.failures = append(kept, now)
+
+	if len(b.failures) >= b.failureThreshold {
+		b.trip(now)
+	}
+}
+
+func (b *CircuitBreaker) trip(now time.Time) {
+	b.state = breakerOpen
+	b.openedAt = now
+	b.failures = nil
+	b.halfOpenProbeInFlight = false
+}
+
+// State returns the breaker's current state as a label suitable for the
+// opcua_breaker_state metric ("closed", "open" or "half-open").
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// Attempts returns the number of consecutive failures recorded since the
+// last success. After a failure this is at least 1; Attempts()-1 is the
+// 0-based attempt number passed to NextBackoff when computing the delay
+// before the next reconnect.
+func (b *CircuitBreaker) Attempts() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.consecutiveFailures
+}
+
+// stateValue maps the breaker's state to the numeric value reported by the
+// opcua_breaker_state gauge (0=closed, 1=half-open, 2=open).
+func (b *CircuitBreaker) stateValue() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		return 1
+	case breakerOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// NextBackoff returns a jittered exponential backoff duration for the given
+// reconnect attempt (0-based), capped at max.
+func NextBackoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	d := base << attempt
+	if d <= 0 || d > max {
+		d = max
+	}
+
+	// Equal jitter: pick a random duration in [d/2, d).
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	BeforeEach(func() {
+		if os.Getenv("TEST_OPCUA_UNIT") == "" {
+			Skip("Skipping OPC UA unit tests: TEST_OPCUA_UNIT not set")
+		}
+	})
+
+	It("starts closed and allows calls", func() {
+		b := NewCircuitBreaker(3, time.Minute, time.Second)
+		Expect(b.Allow()).To(BeTrue())
+		Expect(b.State()).To(Equal("closed"))
+	})
+
+	It("opens after failureThreshold consecutive failures", func() {
+		b := NewCircuitBreaker(3, time.Minute, time.Hour)
+		b.RecordFailure()
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("closed"))
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("open"))
+		Expect(b.Allow()).To(BeFalse())
+	})
+
+	It("ignores failures older than window when counting toward the threshold", func() {
+		b := NewCircuitBreaker(2, 10*time.Millisecond, time.Hour)
+		b.RecordFailure()
+		time.Sleep(20 * time.Millisecond)
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("closed"))
+	})
+
+	It("moves to half-open after cooldown and allows exactly one probe", func() {
+		b := NewCircuitBreaker(1, time.Minute, 10*time.Millisecond)
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("open"))
+
+		time.Sleep(20 * time.Millisecond)
+		Expect(b.Allow()).To(BeTrue())
+		Expect(b.State()).To(Equal("half-open"))
+		Expect(b.Allow()).To(BeFalse())
+	})
+
+	It("rejects a second caller while a half-open probe is still in flight", func() {
+		b := NewCircuitBreaker(1, time.Minute, 10*time.Millisecond)
+		b.RecordFailure()
+		time.Sleep(20 * time.Millisecond)
+
+		Expect(b.Allow()).To(BeTrue())
+		Expect(b.State()).To(Equal("half-open"))
+
+		// The first probe is still outstanding (no RecordSuccess/RecordFailure
+		// yet), so a concurrent/retried caller must be turned away rather than
+		// also being let through to the still-recovering server.
+		Expect(b.Allow()).To(BeFalse())
+
+		b.RecordSuccess()
+		Expect(b.Allow()).To(BeTrue())
+	})
+
+	It("re-opens immediately on a failed half-open probe", func() {
+		b := NewCircuitBreaker(1, time.Minute, 10*time.Millisecond)
+		b.RecordFailure()
+		time.Sleep(20 * time.Millisecond)
+		Expect(b.Allow()).To(BeTrue())
+
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("open"))
+	})
+
+	It("closes and clears failure history on success", func() {
+		b := NewCircuitBreaker(2, time.Minute, time.Hour)
+		b.RecordFailure()
+		b.RecordSuccess()
+		Expect(b.State()).To(Equal("closed"))
+
+		b.RecordFailure()
+		Expect(b.State()).To(Equal("closed"))
+	})
+
+	It("counts consecutive failures for Attempts and resets on success", func() {
+		b := NewCircuitBreaker(5, time.Minute, time.Hour)
+		Expect(b.Attempts()).To(Equal(0))
+
+		b.RecordFailure()
+		b.RecordFailure()
+		Expect(b.Attempts()).To(Equal(2))
+
+		b.RecordSuccess()
+		Expect(b.Attempts()).To(Equal(0))
+	})
+})
+
+var _ = Describe("NextBackoff", func() {
+	BeforeEach(func() {
+		if os.Getenv("TEST_OPCUA_UNIT") == "" {
+			Skip("Skipping OPC UA unit tests: TEST_OPCUA_UNIT not set")
+		}
+	})
+
+	It("grows with the attempt number but never exceeds max", func() {
+		base := 10 * time.Millisecond
+		max := 200 * time.Millisecond
+
+		var prev time.Duration
+		for attempt := 0; attempt < 10; attempt++ {
+			d := NextBackoff(attempt, base, max)
+			Expect(d).To(BeNumerically(">", 0))
+			Expect(d).To(BeNumerically("<=", max))
+			// Until the cap is reached, attempt n lands in [base<<n/2, base<<n),
+			// which lies wholly above the previous attempt's range.
+			if base<<attempt <= max {
+				Expect(d).To(BeNumerically(">", prev))
+			}
+			prev = d
+		}
+	})
+
+	It("clamps negative attempts to zero", func() {
+		d := NextBackoff(-5, 10*time.Millisecond, time.Second)
+		Expect(d).To(BeNumerically(">", 0))
+	})
+})
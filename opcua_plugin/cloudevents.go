@@ -0,0 +1,100 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// OutputFormat selects how getBytesFromValue serializes an OPC UA DataValue
+// into the bytes that end up in the resulting Benthos message.
+type OutputFormat string
+
+const (
+	// OutputFormatRaw is the legacy behavior: scalars are stringified and
+	// tagType is one of "number", "string" or "bool".
+	OutputFormatRaw OutputFormat = ""
+	// OutputFormatCloudEvents wraps the value in a CloudEvents 1.0 envelope
+	// in structured JSON mode, see https://cloudevents.io/.
+	OutputFormatCloudEvents OutputFormat = "cloudevents"
+)
+
+// cloudEventType identifies data-change events emitted by this connection.
+const cloudEventType = "com.umh.opcua.datachange.v1"
+
+// cloudEvent is the structured-mode JSON representation of a CloudEvents 1.0
+// event, see
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+
+	// Extension context attributes, see
+	// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md#extension-context-attributes
+	BrowseName string `json:"browsename"`
+	NodeID     string `json:"nodeid"`
+	StatusCode string `json:"statuscode"`
+}
+
+// cloudEventData is the payload carried in a CloudEvent's "data" field.
+type cloudEventData struct {
+	Value           interface{} `json:"value"`
+	StatusCode      string      `json:"statusCode"`
+	ServerTimestamp string      `json:"serverTimestamp"`
+	SourceTimestamp string      `json:"sourceTimestamp"`
+	DataType        string      `json:"dataType"`
+}
+
+// buildCloudEvent wraps the already-decoded value and tagType for nodeDef
+// into a CloudEvents 1.0 envelope. endpoint is used as the CloudEvent
+// "source" attribute.
+func buildCloudEvent(dataValue *ua.DataValue, nodeDef NodeDef, value interface{}, tagType string, endpoint string) ([]byte, error) {
+	data := cloudEventData{
+		Value:           value,
+		StatusCode:      dataValue.Status.Error(),
+		ServerTimestamp: dataValue.ServerTimestamp.Format(time.RFC3339Nano),
+		SourceTimestamp: dataValue.SourceTimestamp.Format(time.RFC3339Nano),
+		DataType:        tagType,
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              nodeDef.NodeID.String() + "@" + strconv.FormatInt(dataValue.SourceTimestamp.UnixNano(), 10),
+		Source:          endpoint,
+		Type:            cloudEventType,
+		Time:            dataValue.SourceTimestamp.Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            dataBytes,
+		BrowseName:      nodeDef.BrowseName,
+		NodeID:          nodeDef.NodeID.String(),
+		StatusCode:      dataValue.Status.Error(),
+	}
+
+	return json.Marshal(ev)
+}
@@ -19,13 +19,24 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gopcua/opcua/ua"
 	"github.com/redpanda-data/benthos/v4/public/service"
 )
 
+// OutputOptions configures how getBytesFromValue serializes a DataValue.
+type OutputOptions struct {
+	// Format selects the output codec. The zero value (OutputFormatRaw)
+	// preserves the legacy stringified behavior.
+	Format OutputFormat
+	// Endpoint is the OPC UA server endpoint URL, used as the CloudEvents
+	// "source" attribute when Format is OutputFormatCloudEvents.
+	Endpoint string
+}
+
 // getBytesFromValue returns the bytes and the tag type for a given OPC UA DataValue and NodeDef.
-func (g *OPCUAConnection) getBytesFromValue(dataValue *ua.DataValue, nodeDef NodeDef) ([]byte, string) {
+func (g *OPCUAConnection) getBytesFromValue(dataValue *ua.DataValue, nodeDef NodeDef, opts OutputOptions) ([]byte, string) {
 	variant := dataValue.Value
 	if variant == nil {
 		g.Log.Errorf("Variant is nil")
@@ -37,6 +48,30 @@ func (g *OPCUAConnection) getBytesFromValue(dataValue *ua.DataValue, nodeDef Nod
 		return nil, ""
 	}
 
+	if opts.Format == OutputFormatCloudEvents {
+		value, ok := decodedExtensionObjectValue(variant.Value())
+		if !ok {
+			g.Log.Warnf("Skipping node %s: ExtensionObject not decodable (custom UDT not registered)", nodeDef.NodeID.String())
+			return nil, ""
+		}
+		ceBytes, err := buildCloudEvent(dataValue, nodeDef, value, coarseTagType(value), opts.Endpoint)
+		if err != nil {
+			g.Log.Errorf("Error building CloudEvent for node %s: %v", nodeDef.NodeID.String(), err)
+			return nil, ""
+		}
+		return ceBytes, "string"
+	}
+
+	if opts.Format == OutputFormatTypedJSON {
+		tjBytes, ok := buildTypedJSON(dataValue, variant.Value())
+		if !ok {
+			g.Log.Warnf("Skipping node %s: typed_json could not represent type %T (unsupported type or custom UDT not registered)",
+				nodeDef.NodeID.String(), variant.Value())
+			return nil, ""
+		}
+		return tjBytes, "string"
+	}
+
 	b := make([]byte, 0)
 
 	var tagType string
@@ -138,41 +173,149 @@ func (g *OPCUAConnection) getBytesFromValue(dataValue *ua.DataValue, nodeDef Nod
 	return b, tagType
 }
 
+// decodedExtensionObjectValue applies the same "undecoded UDT" guard the raw
+// format uses (case *ua.ExtensionObject / []*ua.ExtensionObject above) to any
+// other output format: it returns ok=false when v is an ExtensionObject (or
+// array thereof) whose binary decoder wasn't registered, so those formats
+// skip the node instead of serializing gopcua's internal, undecoded struct.
+// Any other value is returned unchanged.
+func decodedExtensionObjectValue(v interface{}) (interface{}, bool) {
+	switch ev := v.(type) {
+	case *ua.ExtensionObject:
+		if ev.Value == nil {
+			return nil, false
+		}
+		return ev.Value, true
+	case []*ua.ExtensionObject:
+		decoded := make([]interface{}, 0, len(ev))
+		for _, eo := range ev {
+			if eo != nil && eo.Value != nil {
+				decoded = append(decoded, eo.Value)
+			}
+		}
+		if len(decoded) == 0 {
+			return nil, false
+		}
+		return decoded, true
+	default:
+		return v, true
+	}
+}
+
+// coarseTagType classifies a decoded OPC UA value into the same coarse
+// "number"/"string"/"bool" labels used by the legacy raw output format.
+func coarseTagType(v interface{}) string {
+	switch v.(type) {
+	case float32, float64,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// breaker returns the connection's circuit breaker, initializing it with
+// sane defaults on first use.
+func (g *OPCUAConnection) breaker() *CircuitBreaker {
+	if g.circuitBreaker == nil {
+		g.circuitBreaker = NewCircuitBreaker(5, 30*time.Second, 15*time.Second)
+	}
+	return g.circuitBreaker
+}
+
+// reportBreakerState pushes the breaker's current state to the
+// opcua_breaker_state gauge, if metrics are configured.
+func (g *OPCUAConnection) reportBreakerState() {
+	if g.Metrics == nil {
+		return
+	}
+	g.Metrics.NewGauge("opcua_breaker_state").Set(g.breaker().stateValue())
+}
+
+// reconnectBackoffBase and reconnectBackoffMax bound the jittered exponential
+// delay handleTransportFailure waits before letting Benthos reconnect, so a
+// server that is down doesn't get hammered with reconnect attempts at a fixed
+// cooldown-only cadence.
+const (
+	reconnectBackoffBase = 500 * time.Millisecond
+	reconnectBackoffMax  = 15 * time.Second
+)
+
+// handleTransportFailure records the failure against the circuit breaker and
+// metrics, closes the now-unusable session, waits out a jittered exponential
+// backoff scaled to the number of consecutive failures, and returns the
+// sentinel error that tells Benthos to reconnect.
+func (g *OPCUAConnection) handleTransportFailure(ctx context.Context) error {
+	g.breaker().RecordFailure()
+	g.reportBreakerState()
+	if g.Metrics != nil {
+		g.Metrics.NewCounter("opcua_read_failures_total").Incr(1)
+		// Closing here always triggers a reconnect attempt on the next Read,
+		// so every transport failure handled is also a reconnect counted.
+		g.Metrics.NewCounter("opcua_reconnects_total").Incr(1)
+	}
+	_ = g.Close(ctx)
+
+	backoff := NextBackoff(g.breaker().Attempts()-1, reconnectBackoffBase, reconnectBackoffMax)
+	select {
+	case <-time.After(backoff):
+	case <-ctx.Done():
+	}
+
+	return service.ErrNotConnected
+}
+
 // Read performs a synchronous read operation on the OPC UA server using the provided ReadRequest.
 //
 // This function sends a ReadRequest to the OPC UA server and handles the response. It manages
 // specific error conditions by closing the current session and signaling that the client is
 // no longer connected, prompting reconnection attempts if necessary. Successful reads return
 // the ReadResponse, while errors are appropriately logged and propagated.
+//
+// Calls are gated by a circuit breaker: once consecutive transport failures
+// trip it, Read fails fast with service.ErrNotConnected for a cooldown
+// period instead of hammering a server that is already struggling. Every
+// error path records the outcome against the breaker — including errors that
+// don't match one of the known transport codes below — so a half-open probe
+// can never be left permanently in flight.
 func (g *OPCUAConnection) Read(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	if !g.breaker().Allow() {
+		g.reportBreakerState()
+		return nil, service.ErrNotConnected
+	}
+
 	resp, err := g.Client.Read(ctx, req)
 	if err != nil {
 		g.Log.Errorf("Read failed: %s", err)
 		// if the error is StatusBadSessionIDInvalid, the session has been closed, and we need to reconnect.
 		switch {
 		case errors.Is(err, ua.StatusBadSessionIDInvalid):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		case errors.Is(err, ua.StatusBadCommunicationError):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		case errors.Is(err, ua.StatusBadConnectionClosed):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		case errors.Is(err, ua.StatusBadTimeout):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		case errors.Is(err, ua.StatusBadConnectionRejected):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		case errors.Is(err, ua.StatusBadServerNotConnected):
-			_ = g.Close(ctx)
-			return nil, service.ErrNotConnected
+			return nil, g.handleTransportFailure(ctx)
 		}
 
-		// return error and stop executing this function.
+		// Not one of the known transport codes above, but still a failed
+		// call: record it against the breaker so a half-open probe's
+		// in-flight flag is always cleared, then return the error unchanged
+		// and let the caller retry.
+		g.breaker().RecordFailure()
+		g.reportBreakerState()
 		return nil, err
 	}
 
+	g.breaker().RecordSuccess()
+	g.reportBreakerState()
 	return resp, nil
 }
@@ -15,7 +15,9 @@
 package opcua_plugin
 
 import (
+	"encoding/json"
 	"os"
+	"time"
 
 	"github.com/gopcua/opcua/ua"
 	. "github.com/onsi/ginkgo/v2"
@@ -52,7 +54,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant(&ua.ExtensionObject{Value: nil}),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(BeNil())
 		Expect(tagType).To(BeEmpty())
 	})
@@ -63,7 +65,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant(int32(42)),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(BeNil())
 		Expect(tagType).To(BeEmpty())
 	})
@@ -74,7 +76,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant(int32(42)),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(Equal([]byte("42")))
 		Expect(tagType).To(Equal("number"))
 	})
@@ -85,7 +87,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant(float64(3.14)),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(Equal([]byte("3.14")))
 		Expect(tagType).To(Equal("number"))
 	})
@@ -96,7 +98,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant("hello"),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(Equal([]byte("hello")))
 		Expect(tagType).To(Equal("string"))
 	})
@@ -107,7 +109,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  ua.MustVariant(true),
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(Equal([]byte("true")))
 		Expect(tagType).To(Equal("bool"))
 	})
@@ -118,7 +120,7 @@ var _ = Describe("getBytesFromValue", func() {
 			Value:  nil,
 		}
 
-		b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+		b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 		Expect(b).To(BeNil())
 		Expect(tagType).To(BeEmpty())
 	})
@@ -137,7 +139,7 @@ var _ = Describe("getBytesFromValue", func() {
 				Value:  ua.MustVariant(extObj),
 			}
 
-			b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 			Expect(b).To(BeNil())
 			Expect(tagType).To(BeEmpty())
 		})
@@ -160,7 +162,7 @@ var _ = Describe("getBytesFromValue", func() {
 				Value:  ua.MustVariant(extObj),
 			}
 
-			b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 			Expect(b).NotTo(BeNil())
 			Expect(tagType).To(Equal("string"))
 			Expect(string(b)).To(ContainSubstring("DeadbandValue"))
@@ -184,7 +186,7 @@ var _ = Describe("getBytesFromValue", func() {
 				Value:  ua.MustVariant(extObjs),
 			}
 
-			b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 			Expect(b).To(BeNil())
 			Expect(tagType).To(BeEmpty())
 		})
@@ -212,7 +214,7 @@ var _ = Describe("getBytesFromValue", func() {
 				Value:  ua.MustVariant(extObjs),
 			}
 
-			b, tagType := conn.getBytesFromValue(dataValue, nodeDef)
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{})
 			Expect(b).NotTo(BeNil())
 			Expect(tagType).To(Equal("string"))
 			// Should be a JSON array with exactly one element (the decoded one)
@@ -220,4 +222,170 @@ var _ = Describe("getBytesFromValue", func() {
 			Expect(string(b)).To(ContainSubstring("DeadbandValue"))
 		})
 	})
+
+	Context("CloudEvents output format", func() {
+		It("should wrap a scalar value in a CloudEvents 1.0 envelope", func() {
+			sourceTimestamp := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+			dataValue := &ua.DataValue{
+				Status:          ua.StatusOK,
+				Value:           ua.MustVariant(int32(42)),
+				SourceTimestamp: sourceTimestamp,
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{
+				Format:   OutputFormatCloudEvents,
+				Endpoint: "opc.tcp://plc.example.com:4840",
+			})
+			Expect(tagType).To(Equal("string"))
+
+			var ev map[string]interface{}
+			Expect(json.Unmarshal(b, &ev)).To(Succeed())
+			Expect(ev["specversion"]).To(Equal("1.0"))
+			Expect(ev["source"]).To(Equal("opc.tcp://plc.example.com:4840"))
+			Expect(ev["type"]).To(Equal("com.umh.opcua.datachange.v1"))
+			Expect(ev["datacontenttype"]).To(Equal("application/json"))
+			Expect(ev["browsename"]).To(Equal("TestNode"))
+			Expect(ev["nodeid"]).To(Equal(nodeDef.NodeID.String()))
+			Expect(ev["id"]).To(ContainSubstring(nodeDef.NodeID.String()))
+
+			data, ok := ev["data"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(data["value"]).To(Equal(float64(42)))
+			Expect(data["dataType"]).To(Equal("number"))
+		})
+
+		It("should skip a node whose ExtensionObject UDT is not registered instead of leaking the undecoded struct", func() {
+			extObj := &ua.ExtensionObject{
+				TypeID: &ua.ExpandedNodeID{
+					NodeID: ua.NewNumericNodeID(4, 202),
+				},
+				EncodingMask: ua.ExtensionObjectBinary,
+				Value:        nil,
+			}
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant(extObj),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatCloudEvents})
+			Expect(b).To(BeNil())
+			Expect(tagType).To(BeEmpty())
+		})
+
+		It("should skip a node whose ExtensionObject array has no decodable entries", func() {
+			extObjs := []*ua.ExtensionObject{
+				{TypeID: &ua.ExpandedNodeID{NodeID: ua.NewNumericNodeID(4, 202)}, Value: nil},
+				{TypeID: &ua.ExpandedNodeID{NodeID: ua.NewNumericNodeID(4, 203)}, Value: nil},
+			}
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant(extObjs),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatCloudEvents})
+			Expect(b).To(BeNil())
+			Expect(tagType).To(BeEmpty())
+		})
+	})
+
+	Context("typed_json output format", func() {
+		DescribeTable("should preserve the OPC UA scalar type",
+			func(value interface{}, expectedValue interface{}, expectedType string) {
+				dataValue := &ua.DataValue{
+					Status: ua.StatusOK,
+					Value:  ua.MustVariant(value),
+				}
+
+				b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatTypedJSON})
+				Expect(tagType).To(Equal("string"))
+
+				var tj map[string]interface{}
+				Expect(json.Unmarshal(b, &tj)).To(Succeed())
+				Expect(tj["value"]).To(Equal(expectedValue))
+				Expect(tj["type"]).To(Equal(expectedType))
+				Expect(tj["rank"]).To(Equal(float64(0)))
+			},
+			Entry("Float", float32(1.5), float64(1.5), "Float"),
+			Entry("Double", float64(3.14), float64(3.14), "Double"),
+			Entry("Boolean", true, true, "Boolean"),
+			Entry("String", "hello", "hello", "String"),
+			Entry("SByte", int8(-8), float64(-8), "SByte"),
+			Entry("Int16", int16(-16), float64(-16), "Int16"),
+			Entry("Int32", int32(-7), float64(-7), "Int32"),
+			Entry("Int64 as string", int64(9223372036854775807), "9223372036854775807", "Int64"),
+			Entry("Byte", uint8(8), float64(8), "Byte"),
+			Entry("UInt16", uint16(16), float64(16), "UInt16"),
+			Entry("UInt32", uint32(32), float64(32), "UInt32"),
+			Entry("UInt64 as string", uint64(18446744073709551615), "18446744073709551615", "UInt64"),
+			Entry("generic int as Int64 string", int(-42), "-42", "Int64"),
+			Entry("generic uint as UInt64 string", uint(42), "42", "UInt64"),
+		)
+
+		It("should report rank 1 and element type for a flat array", func() {
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant([]int32{1, 2, 3}),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatTypedJSON})
+			Expect(tagType).To(Equal("string"))
+
+			var tj map[string]interface{}
+			Expect(json.Unmarshal(b, &tj)).To(Succeed())
+			Expect(tj["value"]).To(Equal([]interface{}{float64(1), float64(2), float64(3)}))
+			Expect(tj["type"]).To(Equal("Int32"))
+			Expect(tj["rank"]).To(Equal(float64(1)))
+		})
+
+		It("should report rank 2 for a matrix", func() {
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant([][]float64{{1, 2}, {3, 4}}),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatTypedJSON})
+			Expect(tagType).To(Equal("string"))
+
+			var tj map[string]interface{}
+			Expect(json.Unmarshal(b, &tj)).To(Succeed())
+			Expect(tj["type"]).To(Equal("Double"))
+			Expect(tj["rank"]).To(Equal(float64(2)))
+		})
+
+		It("should use the decoded-value serialization for ExtensionObject arrays", func() {
+			decodedValue := &ua.DataChangeFilter{
+				Trigger:       ua.DataChangeTriggerStatusValue,
+				DeadbandType:  uint32(ua.DeadbandTypeAbsolute),
+				DeadbandValue: 1.5,
+			}
+			extObjs := []*ua.ExtensionObject{
+				{TypeID: &ua.ExpandedNodeID{NodeID: ua.NewNumericNodeID(4, 202)}, Value: nil},
+				{TypeID: &ua.ExpandedNodeID{NodeID: ua.NewNumericNodeID(0, 724)}, Value: decodedValue},
+			}
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant(extObjs),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatTypedJSON})
+			Expect(tagType).To(Equal("string"))
+			Expect(string(b)).To(ContainSubstring("DeadbandValue"))
+			Expect(string(b)).To(ContainSubstring(`"type":"ExtensionObject"`))
+		})
+
+		It("should skip a node whose ExtensionObject UDT is not registered", func() {
+			extObj := &ua.ExtensionObject{
+				TypeID: &ua.ExpandedNodeID{NodeID: ua.NewNumericNodeID(4, 202)},
+				Value:  nil,
+			}
+			dataValue := &ua.DataValue{
+				Status: ua.StatusOK,
+				Value:  ua.MustVariant(extObj),
+			}
+
+			b, tagType := conn.getBytesFromValue(dataValue, nodeDef, OutputOptions{Format: OutputFormatTypedJSON})
+			Expect(b).To(BeNil())
+			Expect(tagType).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,146 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// OutputFormatTypedJSON preserves the OPC UA scalar type, array shape and
+// variant rank instead of coarsely stringifying every value, see
+// typedValue.
+const OutputFormatTypedJSON OutputFormat = "typed_json"
+
+// typedJSON is the wire shape emitted for OutputFormatTypedJSON.
+type typedJSON struct {
+	Value           interface{} `json:"value"`
+	Type            string      `json:"type"`
+	Rank            int         `json:"rank"`
+	Status          string      `json:"status"`
+	SourceTimestamp string      `json:"sourceTimestamp"`
+}
+
+// buildTypedJSON serializes raw, the decoded variant value of dataValue,
+// preserving its OPC UA type and array rank. It returns ok=false when the value cannot
+// be represented, e.g. an ExtensionObject whose UDT decoder isn't registered.
+func buildTypedJSON(dataValue *ua.DataValue, raw interface{}) ([]byte, bool) {
+	value, typeName, rank, ok := typedValue(raw)
+	if !ok {
+		return nil, false
+	}
+
+	tj := typedJSON{
+		Value:           value,
+		Type:            typeName,
+		Rank:            rank,
+		Status:          dataValue.Status.Error(),
+		SourceTimestamp: dataValue.SourceTimestamp.Format(time.RFC3339Nano),
+	}
+
+	b, err := json.Marshal(tj)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// typedValue maps a decoded OPC UA variant value to a JSON-safe
+// representation, its OPC UA built-in type name, and its variant rank
+// (0 for scalars, 1 for arrays, 2+ for matrices). Int64/UInt64 are returned
+// as decimal strings so they survive JSON's float64 number type without loss
+// of precision.
+func typedValue(raw interface{}) (interface{}, string, int, bool) {
+	switch v := raw.(type) {
+	case float32:
+		return v, "Float", 0, true
+	case float64:
+		return v, "Double", 0, true
+	case string:
+		return v, "String", 0, true
+	case bool:
+		return v, "Boolean", 0, true
+	case int:
+		return strconv.FormatInt(int64(v), 10), "Int64", 0, true
+	case int8:
+		return v, "SByte", 0, true
+	case int16:
+		return v, "Int16", 0, true
+	case int32:
+		return v, "Int32", 0, true
+	case int64:
+		return strconv.FormatInt(v, 10), "Int64", 0, true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), "UInt64", 0, true
+	case uint8:
+		return v, "Byte", 0, true
+	case uint16:
+		return v, "UInt16", 0, true
+	case uint32:
+		return v, "UInt32", 0, true
+	case uint64:
+		return strconv.FormatUint(v, 10), "UInt64", 0, true
+	case *ua.ExtensionObject:
+		if v.Value == nil {
+			return nil, "", 0, false
+		}
+		return v.Value, "ExtensionObject", 0, true
+	case []*ua.ExtensionObject:
+		decoded := make([]interface{}, 0, len(v))
+		for _, eo := range v {
+			if eo != nil && eo.Value != nil {
+				decoded = append(decoded, eo.Value)
+			}
+		}
+		if len(decoded) == 0 {
+			return nil, "", 0, false
+		}
+		return decoded, "ExtensionObject", 1, true
+	default:
+		return typedArrayValue(raw)
+	}
+}
+
+// typedArrayValue handles arrays and matrices, which gopcua represents as
+// (nested) Go slices. The rank is one more than the highest rank of its
+// elements, and the reported type name is that of the innermost elements.
+func typedArrayValue(raw interface{}) (interface{}, string, int, bool) {
+	rv := reflect.ValueOf(raw)
+	if rv.Kind() != reflect.Slice {
+		return nil, "", 0, false
+	}
+
+	elems := make([]interface{}, 0, rv.Len())
+	var elemType string
+	elemRank := 0
+	for i := 0; i < rv.Len(); i++ {
+		value, typeName, rank, ok := typedValue(rv.Index(i).Interface())
+		if !ok {
+			continue
+		}
+		elems = append(elems, value)
+		elemType = typeName
+		if rank > elemRank {
+			elemRank = rank
+		}
+	}
+
+	return elems, elemType, elemRank + 1, true
+}
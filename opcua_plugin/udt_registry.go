@@ -0,0 +1,319 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"unicode"
+
+	"github.com/gopcua/opcua/ua"
+	"gopkg.in/yaml.v3"
+)
+
+// FieldDef describes a single field of a user-defined structure (UDT), as
+// found in the OPC UA server's StructureDefinition for a custom DataType.
+type FieldDef struct {
+	// Name is the field name, used verbatim as the generated struct's JSON
+	// key; the Go field name is the same with its first letter upper-cased.
+	Name string `yaml:"name" json:"name"`
+	// Type is the OPC UA built-in type name for this field, e.g. "Int32",
+	// "Double", "String", "Boolean". Ignored when NestedTypeID is set.
+	Type string `yaml:"type" json:"type"`
+	// NestedTypeID is the DataType NodeID (e.g. "ns=4;i=4002") of another
+	// UDT, already registered in the same UDTRegistry, that this field
+	// embeds. Set this instead of Type to describe a nested structure.
+	NestedTypeID string `yaml:"nestedTypeId" json:"nestedTypeId"`
+	// IsArray marks the field as a variable-length array of Type (or of
+	// NestedTypeID).
+	IsArray bool `yaml:"isArray" json:"isArray"`
+}
+
+// UDTDefinition is one custom structure definition, keyed by its DataType
+// NodeID (e.g. "ns=4;i=3002").
+type UDTDefinition struct {
+	TypeID string     `yaml:"typeId" json:"typeId"`
+	Fields []FieldDef `yaml:"fields" json:"fields"`
+}
+
+// udtDefinitionFile is the top-level shape of a YAML config block passed to
+// LoadFromYAML. Files read by LoadFromJSONDir hold a bare UDTDefinition.
+type udtDefinitionFile struct {
+	UDTs []UDTDefinition `yaml:"udts" json:"udts"`
+}
+
+// UDTRegistry loads user-supplied custom UDT (structure) definitions and
+// registers gopcua decoders for them, so that ExtensionObjects of those
+// types arrive at getBytesFromValue already decoded instead of being
+// dropped.
+type UDTRegistry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewUDTRegistry returns an empty UDTRegistry.
+func NewUDTRegistry() *UDTRegistry {
+	return &UDTRegistry{
+		types: make(map[string]reflect.Type),
+	}
+}
+
+// Register builds a runtime struct type matching fields and registers it
+// with gopcua as the decoder for typeID, so future reads of ExtensionObjects
+// carrying that DataType are decoded into instances of that struct. A field
+// with NestedTypeID set is embedded using the struct type already registered
+// under that DataType NodeID, so nested UDTs must be registered bottom-up
+// (innermost structure first); DiscoverFromServer does this automatically.
+func (r *UDTRegistry) Register(typeID ua.NodeID, fields []FieldDef) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	structFields := make([]reflect.StructField, 0, len(fields))
+	for _, f := range fields {
+		goType, err := r.fieldGoType(typeID, f)
+		if err != nil {
+			return err
+		}
+		if f.IsArray {
+			goType = reflect.SliceOf(goType)
+		}
+		fieldName, err := exportedFieldName(f.Name)
+		if err != nil {
+			return fmt.Errorf("udt %s: field %q: %w", typeID.String(), f.Name, err)
+		}
+		structFields = append(structFields, reflect.StructField{
+			Name: fieldName,
+			Type: goType,
+			// Keep the original, possibly lowercase, OPC UA field name on
+			// the wire so decoded UDTs serialize with the names operators
+			// configured rather than the exported Go identifier.
+			Tag: reflect.StructTag(fmt.Sprintf(`json:%q`, f.Name)),
+		})
+	}
+
+	structType := reflect.StructOf(structFields)
+	instance := reflect.New(structType).Interface()
+
+	ua.RegisterExtensionObject(&typeID, instance)
+
+	r.types[typeID.String()] = structType
+
+	return nil
+}
+
+// fieldGoType resolves a FieldDef to the Go type used to represent it: a
+// previously registered nested UDT struct type when NestedTypeID is set, or
+// the builtin scalar mapping otherwise. Callers must hold r.mu.
+func (r *UDTRegistry) fieldGoType(typeID ua.NodeID, f FieldDef) (reflect.Type, error) {
+	if f.NestedTypeID != "" {
+		nested, ok := r.types[f.NestedTypeID]
+		if !ok {
+			return nil, fmt.Errorf("udt %s: field %q references unregistered nested UDT %s (register nested UDTs before their parent)",
+				typeID.String(), f.Name, f.NestedTypeID)
+		}
+		return nested, nil
+	}
+
+	goType, err := goTypeForOPCUAType(f.Type)
+	if err != nil {
+		return nil, fmt.Errorf("udt %s: field %q: %w", typeID.String(), f.Name, err)
+	}
+	return goType, nil
+}
+
+// LoadFromYAML parses an inline YAML config block (the `udts:` list under an
+// input's configuration) and registers every definition it contains.
+func (r *UDTRegistry) LoadFromYAML(data []byte) error {
+	var file udtDefinitionFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parsing UDT YAML: %w", err)
+	}
+	return r.registerAll(file.UDTs)
+}
+
+// LoadFromJSONDir reads every *.json file in dir, each expected to contain a
+// single UDTDefinition, and registers them all.
+func (r *UDTRegistry) LoadFromJSONDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading UDT directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading UDT file %s: %w", path, err)
+		}
+
+		var def UDTDefinition
+		if err := json.Unmarshal(data, &def); err != nil {
+			return fmt.Errorf("parsing UDT file %s: %w", path, err)
+		}
+
+		if err := r.registerAll([]UDTDefinition{def}); err != nil {
+			return fmt.Errorf("registering UDT file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// StructureDefinitionSource reads the DataTypeDefinition attribute for a
+// custom Structure DataType off an OPC UA server. No implementation backed
+// by a live client is provided here; tests substitute a fake that serves
+// definitions published by a mock server.
+type StructureDefinitionSource interface {
+	ReadStructureDefinition(ctx context.Context, typeID ua.NodeID) (*ua.StructureDefinition, error)
+}
+
+// builtinTypeNames maps the standard namespace-0 NodeIDs of the scalar
+// DataTypes Boolean through String (ids 1-12) to the type name accepted by
+// FieldDef.Type / goTypeForOPCUAType; other built-in DataTypes are not
+// mapped, see OPC UA Part 6, Table A.1 "Mapping of OPC UA
+// Built-in Types".
+var builtinTypeNames = map[uint32]string{
+	1:  "Boolean",
+	2:  "SByte",
+	3:  "Byte",
+	4:  "Int16",
+	5:  "UInt16",
+	6:  "Int32",
+	7:  "UInt32",
+	8:  "Int64",
+	9:  "UInt64",
+	10: "Float",
+	11: "Double",
+	12: "String",
+}
+
+// DiscoverFromServer browses the DataTypeDefinition attribute of typeID via
+// source, recursing into any nested custom Structure fields first so they're
+// registered before the structure that embeds them, and registers a decoder
+// for every Structure it finds.
+func (r *UDTRegistry) DiscoverFromServer(ctx context.Context, typeID ua.NodeID, source StructureDefinitionSource) error {
+	def, err := source.ReadStructureDefinition(ctx, typeID)
+	if err != nil {
+		return fmt.Errorf("reading DataTypeDefinition for %s: %w", typeID.String(), err)
+	}
+
+	fields := make([]FieldDef, 0, len(def.Fields))
+	for _, sf := range def.Fields {
+		field := FieldDef{
+			Name:    sf.Name,
+			IsArray: sf.ValueRank >= 1,
+		}
+
+		if sf.DataType.Namespace() == 0 {
+			typeName, ok := builtinTypeNames[sf.DataType.IntID()]
+			if !ok {
+				return fmt.Errorf("udt %s: field %q: unsupported builtin DataType %s",
+					typeID.String(), sf.Name, sf.DataType.String())
+			}
+			field.Type = typeName
+		} else {
+			// Custom nested structure: discover and register it first so
+			// Register can resolve it by NestedTypeID below.
+			if err := r.DiscoverFromServer(ctx, sf.DataType, source); err != nil {
+				return fmt.Errorf("udt %s: field %q: %w", typeID.String(), sf.Name, err)
+			}
+			field.NestedTypeID = sf.DataType.String()
+		}
+
+		fields = append(fields, field)
+	}
+
+	return r.Register(typeID, fields)
+}
+
+func (r *UDTRegistry) registerAll(defs []UDTDefinition) error {
+	for _, def := range defs {
+		typeID, err := ua.ParseNodeID(def.TypeID)
+		if err != nil {
+			return fmt.Errorf("parsing UDT type id %q: %w", def.TypeID, err)
+		}
+		if err := r.Register(*typeID, def.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportedFieldName turns an OPC UA field name into a valid exported Go
+// struct field name by upper-casing its first letter. reflect.StructOf
+// panics if asked to build an unexported field with no PkgPath, so this
+// must be applied to every field name before it reaches reflect.StructField.
+func exportedFieldName(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("field name must not be empty")
+	}
+
+	r := []rune(name)
+	// A leading underscore would stay unexported after upper-casing.
+	if !unicode.IsLetter(r[0]) {
+		return "", fmt.Errorf("field name %q must start with a letter", name)
+	}
+	for _, c := range r[1:] {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			return "", fmt.Errorf("field name %q may only contain letters, digits and underscores", name)
+		}
+	}
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r), nil
+}
+
+// goTypeForOPCUAType maps an OPC UA built-in type name to the Go type used
+// to represent it, matching the mapping already applied to scalar values in
+// getBytesFromValue and typedValue.
+func goTypeForOPCUAType(name string) (reflect.Type, error) {
+	switch name {
+	case "Boolean":
+		return reflect.TypeOf(bool(false)), nil
+	case "SByte":
+		return reflect.TypeOf(int8(0)), nil
+	case "Byte":
+		return reflect.TypeOf(uint8(0)), nil
+	case "Int16":
+		return reflect.TypeOf(int16(0)), nil
+	case "UInt16":
+		return reflect.TypeOf(uint16(0)), nil
+	case "Int32":
+		return reflect.TypeOf(int32(0)), nil
+	case "UInt32":
+		return reflect.TypeOf(uint32(0)), nil
+	case "Int64":
+		return reflect.TypeOf(int64(0)), nil
+	case "UInt64":
+		return reflect.TypeOf(uint64(0)), nil
+	case "Float":
+		return reflect.TypeOf(float32(0)), nil
+	case "Double":
+		return reflect.TypeOf(float64(0)), nil
+	case "String":
+		return reflect.TypeOf(string("")), nil
+	default:
+		return nil, fmt.Errorf("unsupported UDT field type %q", name)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 UMH Systems GmbH
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opcua_plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/gopcua/opcua/ua"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// mockStructureServer stands in for an OPC UA server that publishes custom
+// UDT definitions via the DataTypeDefinition attribute, so DiscoverFromServer
+// can be exercised without a live OPC UA connection.
+type mockStructureServer struct {
+	definitions map[string]*ua.StructureDefinition
+}
+
+func (m *mockStructureServer) ReadStructureDefinition(_ context.Context, typeID ua.NodeID) (*ua.StructureDefinition, error) {
+	def, ok := m.definitions[typeID.String()]
+	if !ok {
+		return nil, fmt.Errorf("mock server: no DataTypeDefinition published for %s", typeID.String())
+	}
+	return def, nil
+}
+
+var _ = Describe("UDTRegistry", func() {
+	BeforeEach(func() {
+		if os.Getenv("TEST_OPCUA_UNIT") == "" {
+			Skip("Skipping OPC UA unit tests: TEST_OPCUA_UNIT not set")
+		}
+	})
+
+	var registry *UDTRegistry
+
+	BeforeEach(func() {
+		registry = NewUDTRegistry()
+	})
+
+	// registeredInstance returns a zero-valued instance of the struct type
+	// registered under key, or nil if no such type was registered.
+	registeredInstance := func(key string) interface{} {
+		registry.mu.RLock()
+		defer registry.mu.RUnlock()
+
+		t, ok := registry.types[key]
+		if !ok {
+			return nil
+		}
+		return reflect.New(t).Interface()
+	}
+
+	It("registers a flat UDT and generates a matching struct type", func() {
+		typeID := ua.NewNumericNodeID(4, 3001)
+		Expect(registry.Register(typeID, []FieldDef{
+			{Name: "Speed", Type: "Double"},
+			{Name: "Running", Type: "Boolean"},
+		})).To(Succeed())
+
+		instance := registeredInstance(typeID.String())
+		Expect(instance).NotTo(BeNil())
+
+		b, err := json.Marshal(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("Speed"))
+		Expect(string(b)).To(ContainSubstring("Running"))
+	})
+
+	It("registers a UDT with an array field", func() {
+		typeID := ua.NewNumericNodeID(4, 3002)
+		Expect(registry.Register(typeID, []FieldDef{
+			{Name: "Samples", Type: "Double", IsArray: true},
+		})).To(Succeed())
+
+		Expect(registeredInstance(typeID.String())).NotTo(BeNil())
+	})
+
+	It("rejects an unsupported field type", func() {
+		typeID := ua.NewNumericNodeID(4, 3003)
+		err := registry.Register(typeID, []FieldDef{
+			{Name: "Blob", Type: "ByteString"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("capitalizes a lowercase field name instead of panicking in reflect.StructOf", func() {
+		typeID := ua.NewNumericNodeID(4, 3004)
+
+		var register func()
+		register = func() {
+			Expect(registry.Register(typeID, []FieldDef{
+				{Name: "speed", Type: "Double"},
+			})).To(Succeed())
+		}
+		Expect(register).NotTo(Panic())
+
+		instance := registeredInstance(typeID.String())
+		Expect(instance).NotTo(BeNil())
+
+		b, err := json.Marshal(instance)
+		Expect(err).NotTo(HaveOccurred())
+		// The wire name stays lowercase even though the generated Go field
+		// had to be capitalized to be exported.
+		Expect(string(b)).To(ContainSubstring(`"speed"`))
+	})
+
+	It("loads definitions from an inline YAML block", func() {
+		yamlBlock := []byte(`
+udts:
+  - typeId: "ns=4;i=3010"
+    fields:
+      - name: Temperature
+        type: Double
+      - name: Alarm
+        type: Boolean
+`)
+		Expect(registry.LoadFromYAML(yamlBlock)).To(Succeed())
+
+		Expect(registeredInstance("ns=4;i=3010")).NotTo(BeNil())
+	})
+
+	Context("DiscoverFromServer", func() {
+		It("registers a nested UDT published by a mock server and decodes it to the expected JSON shape", func() {
+			stepType := ua.NewNumericNodeID(4, 4002)
+			recipeType := ua.NewNumericNodeID(4, 4001)
+
+			server := &mockStructureServer{
+				definitions: map[string]*ua.StructureDefinition{
+					stepType.String(): {
+						Fields: []*ua.StructureField{
+							{Name: "DurationSeconds", DataType: ua.NewNumericNodeID(0, 7), ValueRank: -1}, // UInt32
+						},
+					},
+					recipeType.String(): {
+						Fields: []*ua.StructureField{
+							{Name: "Name", DataType: ua.NewNumericNodeID(0, 12), ValueRank: -1}, // String
+							{Name: "FirstStep", DataType: stepType, ValueRank: -1},
+						},
+					},
+				},
+			}
+
+			Expect(registry.DiscoverFromServer(context.Background(), recipeType, server)).To(Succeed())
+
+			// The nested Step UDT must have been registered too, not just the
+			// top-level Recipe.
+			stepInstance := registeredInstance(stepType.String())
+			Expect(stepInstance).NotTo(BeNil())
+
+			recipeInstance := registeredInstance(recipeType.String())
+			Expect(recipeInstance).NotTo(BeNil())
+
+			b, err := json.Marshal(recipeInstance)
+			Expect(err).NotTo(HaveOccurred())
+
+			var shape map[string]interface{}
+			Expect(json.Unmarshal(b, &shape)).To(Succeed())
+			Expect(shape).To(HaveKey("Name"))
+			Expect(shape).To(HaveKey("FirstStep"))
+
+			firstStep, ok := shape["FirstStep"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(firstStep).To(HaveKey("DurationSeconds"))
+		})
+
+		It("surfaces the mock server's error instead of registering a partial UDT", func() {
+			unknownType := ua.NewNumericNodeID(4, 4099)
+			server := &mockStructureServer{definitions: map[string]*ua.StructureDefinition{}}
+
+			err := registry.DiscoverFromServer(context.Background(), unknownType, server)
+			Expect(err).To(HaveOccurred())
+			Expect(registeredInstance(unknownType.String())).To(BeNil())
+		})
+	})
+})